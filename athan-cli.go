@@ -7,8 +7,10 @@ import (
         "io"
         "net/http"
         "os"
-        "sort"
         "time"
+
+        "github.com/Anes201/athan-cli/internal/geocode"
+        "github.com/Anes201/athan-cli/internal/prayer"
 )
 
 type PrayerTimes struct {
@@ -24,25 +26,14 @@ type PrayerTimes struct {
         } `json:"data"`
 }
 
-type GeocodeResponse struct {
-        Results []struct {
-                Geometry struct {
-                        Location struct {
-                                Lat float64 `json:"lat"`
-                                Lng float64 `json:"lng"`
-                        } `json:"location"`
-                } `json:"geometry"`
-        } `json:"results"`
-}
-
 type Prayer struct {
         Name string
         Time string
 }
 
-func getPrayerTimes(latitude, longitude float64, method int) (*PrayerTimes, error) {
-        today := time.Now().Format("02-01-2006")
-        url := fmt.Sprintf("http://api.aladhan.com/v1/timings/%s?latitude=%f&longitude=%f&method=%d", today, latitude, longitude, method)
+func getPrayerTimes(latitude, longitude float64, method int, date time.Time) (*PrayerTimes, error) {
+        day := date.Format("02-01-2006")
+        url := fmt.Sprintf("http://api.aladhan.com/v1/timings/%s?latitude=%f&longitude=%f&method=%d", day, latitude, longitude, method)
 
         resp, err := http.Get(url)
         if err != nil {
@@ -76,6 +67,67 @@ func parseTime(timeStr string) (time.Time, error) {
         return time.Parse("15:04", timeStr)
 }
 
+// mapMethod translates an Aladhan API method id to the closest matching
+// offline calculation convention.
+func mapMethod(id int) prayer.Method {
+        switch id {
+        case 0:
+                return prayer.Jafari
+        case 1:
+                return prayer.Karachi
+        case 2:
+                return prayer.ISNA
+        case 4:
+                return prayer.UmmAlQura
+        case 5:
+                return prayer.Egyptian
+        case 7:
+                return prayer.Tehran
+        default:
+                return prayer.MWL
+        }
+}
+
+// computeOffline derives prayer times locally via the prayer package and
+// packs them into a PrayerTimes value so the existing rendering code can
+// handle offline and API results identically.
+func computeOffline(latitude, longitude float64, method int) (*PrayerTimes, error) {
+        return computeOfflineOn(latitude, longitude, method, time.Now())
+}
+
+// computeOfflineOn is computeOffline for an arbitrary calendar date, used
+// by fetchSchedule to build multi-day offline schedules.
+func computeOfflineOn(latitude, longitude float64, method int, date time.Time) (*PrayerTimes, error) {
+        times, err := prayer.Compute(latitude, longitude, date, prayer.Params{
+                Method:           mapMethod(method),
+                HighLatitudeRule: prayer.AngleBased,
+        })
+        if err != nil {
+                return nil, fmt.Errorf("offline calculation failed: %w", err)
+        }
+
+        var pt PrayerTimes
+        pt.Code = 200
+        pt.Data.Date.Readable = date.Format("02 Jan 2006")
+        pt.Data.Timings = timingsFromTimes(times)
+        return &pt, nil
+}
+
+// timingsFromTimes formats a prayer.Times value the same way the Aladhan
+// API formats its timings map.
+func timingsFromTimes(times prayer.Times) map[string]string {
+        return map[string]string{
+                "Imsak":    times.Imsak.Format("15:04"),
+                "Fajr":     times.Fajr.Format("15:04"),
+                "Sunrise":  times.Sunrise.Format("15:04"),
+                "Dhuhr":    times.Dhuhr.Format("15:04"),
+                "Asr":      times.Asr.Format("15:04"),
+                "Maghrib":  times.Maghrib.Format("15:04"),
+                "Isha":     times.Isha.Format("15:04"),
+                "Midnight": times.Midnight.Format("15:04"),
+        }
+}
+
 func calculateTimeUntilNextPrayer(prayerTimes map[string]string) (string, time.Duration, error) {
         now := time.Now()
 
@@ -105,38 +157,38 @@ func calculateTimeUntilNextPrayer(prayerTimes map[string]string) (string, time.D
         return nextPrayerName, minDuration, nil
 }
 
-func geocodeCity(city string) (float64, float64, error) {
-        apiKey := os.Getenv("GOOGLE_MAPS_API_KEY")
-        if apiKey == "" {
-                return 0, 0, fmt.Errorf("GOOGLE_MAPS_API_KEY environment variable not set")
-        }
-
-        url := fmt.Sprintf("https://maps.googleapis.com/maps/api/geocode/json?address=%s&key=%s", city, apiKey)
-        resp, err := http.Get(url)
+// resolveCity geocodes city through the named provider, using cache (when
+// non-nil) to avoid repeat lookups. When the provider returns more than
+// one candidate, pick (1-based) selects one; with pick 0 it prints the
+// candidates for disambiguation and uses the first result.
+func resolveCity(city, provider string, cache *geocode.Cache, pick int) (float64, float64, error) {
+        geocoder, err := geocode.New(provider)
         if err != nil {
-                return 0, 0, fmt.Errorf("geocode HTTP request failed: %w", err)
+                return 0, 0, err
         }
-        defer resp.Body.Close()
 
-        body, err := io.ReadAll(resp.Body)
+        results, err := geocode.Lookup(geocoder, cache, city)
         if err != nil {
-                return 0, 0, fmt.Errorf("failed to read geocode response body: %w", err)
+                return 0, 0, err
         }
 
-        var geocodeResponse GeocodeResponse
-        err = json.Unmarshal(body, &geocodeResponse)
-        if err != nil {
-                return 0, 0, fmt.Errorf("failed to decode geocode JSON: %w", err)
+        if len(results) > 1 && pick == 0 {
+                fmt.Println("Multiple locations found, pick one with -pick=N:")
+                for i, r := range results {
+                        fmt.Printf("  %d: %s (%.4f, %.4f)\n", i+1, r.Name, r.Lat, r.Lng)
+                }
         }
 
-        if len(geocodeResponse.Results) == 0 {
-                return 0, 0, fmt.Errorf("city not found")
+        index := 0
+        if pick > 0 {
+                index = pick - 1
+        }
+        if index < 0 || index >= len(results) {
+                return 0, 0, fmt.Errorf("resolveCity: -pick=%d out of range (1-%d)", pick, len(results))
         }
 
-        lat := geocodeResponse.Results[0].Geometry.Location.Lat
-        lng := geocodeResponse.Results[0].Geometry.Location.Lng
-
-        return lat, lng, nil
+        chosen := results[index]
+        return chosen.Lat, chosen.Lng, nil
 }
 
 func main() {
@@ -144,14 +196,41 @@ func main() {
         lat := flag.Float64("lat", 0, "Latitude for prayer times")
         lng := flag.Float64("lng", 0, "Longitude for prayer times")
         method := flag.Int("method", 19, "Calculation method")
+        offline := flag.Bool("offline", false, "Compute prayer times locally instead of calling api.aladhan.com")
+        daemon := flag.Bool("daemon", false, "Stay resident and send notifications at each prayer time")
+        adhanFile := flag.String("adhan-file", "", "Audio file to play at each prayer time when in -daemon mode")
+        geocoder := flag.String("geocoder", "google", "Geocoding provider to use for -city: google, nominatim or owm")
+        pick := flag.Int("pick", 0, "1-based index to choose among multiple -city matches")
+        geocodeTTL := flag.Duration("geocode-cache-ttl", 30*24*time.Hour, "How long cached -city lookups remain valid")
+        format := flag.String("format", "text", "Output format: text, json or ics")
+        rangeFlag := flag.String("range", "today", "Schedule range to export: today, week or month")
+        eventDuration := flag.Duration("event-duration", 10*time.Minute, "Event duration used by -format=ics")
+        reminder := flag.Duration("reminder", 15*time.Minute, "VALARM lead time used by -format=ics")
+        serve := flag.String("serve", "", "Run an HTTP server on this address (e.g. :8080) instead of a one-shot lookup")
 
         flag.Parse()
 
+        if *serve != "" {
+                if err := runServe(*serve, *method, *offline, *geocoder); err != nil {
+                        fmt.Println("Error:", err)
+                }
+                return
+        }
+
         var latitude, longitude float64
         var err error
 
         if *city != "" {
-                latitude, longitude, err = geocodeCity(*city)
+                cachePath, cacheErr := geocode.DefaultCachePath()
+                var cache *geocode.Cache
+                if cacheErr == nil {
+                        cache, cacheErr = geocode.OpenCache(cachePath, *geocodeTTL)
+                }
+                if cacheErr != nil {
+                        fmt.Println("Warning: geocode cache unavailable:", cacheErr)
+                }
+
+                latitude, longitude, err = resolveCity(*city, *geocoder, cache, *pick)
                 if err != nil {
                         fmt.Println("Error:", err)
                         return
@@ -164,46 +243,52 @@ func main() {
                 return
         }
 
-        prayerTimes, err := getPrayerTimes(latitude, longitude, *method)
-        if err != nil {
-                fmt.Println("Error:", err)
+        if *format != "text" || *rangeFlag != "today" {
+                schedule, err := fetchSchedule(latitude, longitude, *method, *offline, *rangeFlag)
+                if err != nil {
+                        fmt.Println("Error:", err)
+                        return
+                }
+                if err := writeSchedule(os.Stdout, *format, schedule, *eventDuration, *reminder); err != nil {
+                        fmt.Println("Error:", err)
+                }
                 return
         }
 
-        fmt.Println("Islamic Prayer Times:")
-        fmt.Printf("Date: %s\n", prayerTimes.Data.Date.Readable)
-        // fmt.Printf("Hijri Date: %s\n\n", prayerTimes.Data.Date.Hijri.Readable)
-        fmt.Println("----------------------")
-
-        var prayers []Prayer
-        for prayerName, prayerTime := range prayerTimes.Data.Timings {
-                prayers = append(prayers, Prayer{Name: prayerName, Time: prayerTime})
+        fetch := func() (*PrayerTimes, error) {
+                if *offline {
+                        return computeOffline(latitude, longitude, *method)
+                }
+                return getPrayerTimes(latitude, longitude, *method, time.Now())
         }
 
-        sort.Slice(prayers, func(i, j int) bool {
-                timeI, _ := parseTime(prayers[i].Time)
-                timeJ, _ := parseTime(prayers[j].Time)
-
-                now := time.Now()
-                timeI = time.Date(now.Year(), now.Month(), now.Day(), timeI.Hour(), timeI.Minute(), 0, 0, now.Location())
-                timeJ = time.Date(now.Year(), now.Month(), now.Day(), timeJ.Hour(), timeJ.Minute(), 0, 0, now.Location())
-
-                return timeI.Before(timeJ)
-        })
-
-        for _, prayer := range prayers {
-                fmt.Printf("%-8s \t: %s\n", prayer.Name, prayer.Time)
+        if *daemon {
+                cfg := daemonConfig{
+                        Latitude:  latitude,
+                        Longitude: longitude,
+                        Method:    *method,
+                        Offline:   *offline,
+                        AdhanFile: *adhanFile,
+                }
+                if err := runDaemon(cfg, fetch); err != nil {
+                        fmt.Println("Error:", err)
+                }
+                return
         }
 
-        nextPrayerName, duration, err := calculateTimeUntilNextPrayer(prayerTimes.Data.Timings)
+        prayerTimes, err := fetch()
         if err != nil {
-                fmt.Println("Error calculating time until next prayer:", err)
+                fmt.Println("Error:", err)
                 return
         }
 
-        hours := int(duration.Hours())
-        minutes := int(duration.Minutes()) % 60
-        seconds := int(duration.Seconds()) % 60
+        if err := runOnce(os.Stdout, prayerTimes); err != nil {
+                fmt.Println("Error:", err)
+        }
+}
 
-        fmt.Printf("\nTime Until Next Prayer (%s): %02d:%02d:%02d\n", nextPrayerName, hours, minutes, seconds)
+// runOnce prints a day's prayer schedule and the countdown to the next
+// prayer. It is shared by the one-shot CLI path and each daemon tick.
+func runOnce(w io.Writer, prayerTimes *PrayerTimes) error {
+        return Render(w, prayerTimes, RenderOptions{})
 }
@@ -0,0 +1,185 @@
+package main
+
+import (
+        "fmt"
+        "log/slog"
+        "os"
+        "os/exec"
+        "os/signal"
+        "runtime"
+        "sync"
+        "syscall"
+        "time"
+)
+
+// daemonConfig holds the parameters runDaemon uses to fetch timings and
+// fire notifications.
+type daemonConfig struct {
+        Latitude, Longitude float64
+        Method              int
+        Offline             bool
+        AdhanFile           string
+}
+
+// cacheKey identifies a cached day's prayer times for a given location,
+// method and calendar date, so repeated lookups during the same day don't
+// re-hit the network (or redo the offline calculation).
+type cacheKey struct {
+        Lat, Lng float64
+        Method   int
+        Date     string
+}
+
+var timingsCache sync.Map // cacheKey -> *PrayerTimes
+
+// cachedFetch wraps fetch with timingsCache, keyed on today's date.
+func cachedFetch(cfg daemonConfig, fetch func() (*PrayerTimes, error)) (*PrayerTimes, error) {
+        key := cacheKey{cfg.Latitude, cfg.Longitude, cfg.Method, time.Now().Format("2006-01-02")}
+        if v, ok := timingsCache.Load(key); ok {
+                return v.(*PrayerTimes), nil
+        }
+        pt, err := fetch()
+        if err != nil {
+                return nil, err
+        }
+        timingsCache.Store(key, pt)
+        return pt, nil
+}
+
+var daemonLog = slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+// runDaemon keeps the process resident. Each cycle it fetches (or reuses
+// the cached) schedule for today, prints it once via runOnce, then sleeps
+// until whichever comes first: the next prayer, a refresh shortly after
+// midnight to pick up tomorrow's schedule, or a refresh 10 minutes before
+// Fajr to guard against the day's schedule having been computed before a
+// clock correction. SIGHUP clears the cache and forces an immediate
+// refresh on the next cycle.
+//
+// This is a hand-rolled time.Timer/select loop rather than a cron
+// dependency such as robfig/cron/v3: this tree has no go.sum and no
+// network access to vendor one, and the only schedule this daemon ever
+// needs is "wake at the next one of these three computed instants", which
+// a single timer expresses directly. A real cron library would earn its
+// keep if this grew config-file schedule expressions or multiple
+// independent jobs; today it would only add a dependency for a feature
+// this code doesn't use.
+func runDaemon(cfg daemonConfig, fetch func() (*PrayerTimes, error)) error {
+        reload := make(chan os.Signal, 1)
+        signal.Notify(reload, syscall.SIGHUP)
+        defer signal.Stop(reload)
+
+        for {
+                pt, err := cachedFetch(cfg, fetch)
+                if err != nil {
+                        daemonLog.Error("fetch prayer times failed", "error", err)
+                        time.Sleep(time.Minute)
+                        continue
+                }
+
+                if err := runOnce(os.Stdout, pt); err != nil {
+                        daemonLog.Error("render prayer times failed", "error", err)
+                }
+
+                nextName, untilNext, err := calculateTimeUntilNextPrayer(pt.Data.Timings)
+                if err != nil {
+                        daemonLog.Error("compute next prayer failed", "error", err)
+                        time.Sleep(time.Minute)
+                        continue
+                }
+
+                wake, cause := untilNext, "prayer"
+                if d := time.Until(nextMidnight()); d < wake {
+                        wake, cause = d, "midnight-refresh"
+                }
+                if fajrAt, ok := todayFajr(pt); ok {
+                        if guard := time.Until(fajrAt.Add(-10 * time.Minute)); guard > 0 && guard < wake {
+                                wake, cause = guard, "fajr-guard"
+                        }
+                }
+
+                daemonLog.Info("sleeping", "cause", cause, "duration", wake.Round(time.Second))
+
+                timer := time.NewTimer(wake)
+                select {
+                case <-timer.C:
+                        switch cause {
+                        case "prayer":
+                                notify(nextName, cfg.AdhanFile)
+                        default:
+                                key := cacheKey{cfg.Latitude, cfg.Longitude, cfg.Method, time.Now().Format("2006-01-02")}
+                                timingsCache.Delete(key)
+                        }
+                case <-reload:
+                        timer.Stop()
+                        daemonLog.Info("SIGHUP received, clearing cache")
+                        timingsCache.Range(func(k, _ any) bool { timingsCache.Delete(k); return true })
+                }
+        }
+}
+
+// nextMidnight returns the start of tomorrow in local time, plus a small
+// delay so the upstream API's "today" has rolled over by the time we ask.
+func nextMidnight() time.Time {
+        now := time.Now()
+        tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 5, 0, 0, now.Location())
+        return tomorrow
+}
+
+// todayFajr parses pt's Fajr timing onto today's calendar date.
+func todayFajr(pt *PrayerTimes) (time.Time, bool) {
+        raw, ok := pt.Data.Timings["Fajr"]
+        if !ok {
+                return time.Time{}, false
+        }
+        t, err := parseTime(raw)
+        if err != nil {
+                return time.Time{}, false
+        }
+        now := time.Now()
+        return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location()), true
+}
+
+// notify fires a desktop notification for the given prayer and, if
+// adhanFile is set, plays it through the platform's audio player.
+func notify(prayerName, adhanFile string) {
+        title := "Athan"
+        body := fmt.Sprintf("It's time for %s", prayerName)
+
+        var cmd *exec.Cmd
+        switch runtime.GOOS {
+        case "linux":
+                cmd = exec.Command("notify-send", title, body)
+        case "darwin":
+                script := fmt.Sprintf(`display notification %q with title %q`, body, title)
+                cmd = exec.Command("osascript", "-e", script)
+        }
+        if cmd != nil {
+                if err := cmd.Run(); err != nil {
+                        daemonLog.Warn("desktop notification failed", "error", err)
+                }
+        } else {
+                daemonLog.Info(body)
+        }
+
+        if adhanFile == "" {
+                return
+        }
+
+        var player *exec.Cmd
+        switch runtime.GOOS {
+        case "linux":
+                player = exec.Command("paplay", adhanFile)
+        case "darwin":
+                player = exec.Command("afplay", adhanFile)
+        default:
+                daemonLog.Warn("don't know how to play audio on this platform", "os", runtime.GOOS)
+                return
+        }
+
+        go func() {
+                if err := player.Run(); err != nil {
+                        daemonLog.Warn("adhan playback failed", "error", err)
+                }
+        }()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+        "testing"
+        "time"
+)
+
+func TestCachedFetchReusesResult(t *testing.T) {
+        cfg := daemonConfig{Latitude: 21.4225, Longitude: 39.8262, Method: 4, Offline: true}
+        calls := 0
+        fetch := func() (*PrayerTimes, error) {
+                calls++
+                var pt PrayerTimes
+                pt.Code = 200
+                return &pt, nil
+        }
+
+        if _, err := cachedFetch(cfg, fetch); err != nil {
+                t.Fatalf("cachedFetch: %v", err)
+        }
+        if _, err := cachedFetch(cfg, fetch); err != nil {
+                t.Fatalf("cachedFetch (second call): %v", err)
+        }
+        if calls != 1 {
+                t.Errorf("fetch called %d times, want 1 (second cachedFetch should hit timingsCache)", calls)
+        }
+}
+
+func TestTodayFajr(t *testing.T) {
+        var pt PrayerTimes
+        pt.Data.Timings = map[string]string{"Fajr": "05:31"}
+
+        got, ok := todayFajr(&pt)
+        if !ok {
+                t.Fatal("todayFajr: expected ok=true")
+        }
+        now := time.Now()
+        if got.Hour() != 5 || got.Minute() != 31 || got.Year() != now.Year() || got.YearDay() != now.YearDay() {
+                t.Errorf("todayFajr = %v, want today at 05:31", got)
+        }
+
+        pt.Data.Timings = map[string]string{}
+        if _, ok := todayFajr(&pt); ok {
+                t.Error("todayFajr: expected ok=false when Fajr is missing")
+        }
+}
+
+func TestNextMidnightIsTomorrowShortlyAfterMidnight(t *testing.T) {
+        now := time.Now()
+        got := nextMidnight()
+        if got.Hour() != 0 || got.Minute() != 5 {
+                t.Errorf("nextMidnight = %v, want 00:05", got)
+        }
+        if got.YearDay() == now.YearDay() && got.Year() == now.Year() {
+                t.Error("nextMidnight returned today instead of tomorrow")
+        }
+}
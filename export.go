@@ -0,0 +1,312 @@
+package main
+
+import (
+        "encoding/json"
+        "fmt"
+        "io"
+        "net/http"
+        "os"
+        "sort"
+        "strings"
+        "time"
+)
+
+// DaySchedule is one calendar day's prayer timings, used to build multi-day
+// exports (-range=week/month) on top of the same text/json/ics renderers.
+type DaySchedule struct {
+        Date    time.Time
+        Hijri   string
+        Timings map[string]string
+}
+
+// fetchSchedule resolves prayer timings for every day in rng: "today" and
+// "week" start today, "month" covers the whole current calendar month from
+// its first day. Online "month" schedules are pulled
+// from Aladhan's /calendar endpoint in a single request instead of one
+// request per day.
+func fetchSchedule(latitude, longitude float64, method int, offline bool, rng string) ([]DaySchedule, error) {
+        today := time.Now()
+
+        switch rng {
+        case "", "today":
+                return fetchDays(latitude, longitude, method, offline, today, 1)
+        case "week":
+                return fetchDays(latitude, longitude, method, offline, today, 7)
+        case "month":
+                if offline {
+                        start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+                        return fetchDays(latitude, longitude, method, offline, start, daysIn(today.Year(), today.Month()))
+                }
+                return fetchMonthOnline(latitude, longitude, method, today)
+        default:
+                return nil, fmt.Errorf("unknown -range %q", rng)
+        }
+}
+
+func daysIn(year int, month time.Month) int {
+        return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// fetchDays builds a schedule for n consecutive days starting at start,
+// fetching (or computing) each day individually.
+func fetchDays(latitude, longitude float64, method int, offline bool, start time.Time, n int) ([]DaySchedule, error) {
+        schedule := make([]DaySchedule, 0, n)
+        for i := 0; i < n; i++ {
+                date := start.AddDate(0, 0, i)
+
+                if offline {
+                        pt, err := computeOfflineOn(latitude, longitude, method, date)
+                        if err != nil {
+                                return nil, err
+                        }
+                        schedule = append(schedule, DaySchedule{Date: date, Timings: pt.Data.Timings})
+                        continue
+                }
+
+                pt, err := getPrayerTimes(latitude, longitude, method, date)
+                if err != nil {
+                        return nil, err
+                }
+                schedule = append(schedule, DaySchedule{Date: date, Hijri: pt.Data.Date.Hijri.Readable, Timings: pt.Data.Timings})
+        }
+        return schedule, nil
+}
+
+// calendarResponse mirrors the relevant fields of Aladhan's
+// /v1/calendar/{year}/{month} response.
+type calendarResponse struct {
+        Code int `json:"code"`
+        Data []struct {
+                Timings map[string]string `json:"timings"`
+                Date    struct {
+                        Gregorian struct {
+                                Date string `json:"date"`
+                        } `json:"gregorian"`
+                        Hijri struct {
+                                Readable string `json:"readable"`
+                        } `json:"hijri"`
+                } `json:"date"`
+        } `json:"data"`
+}
+
+func fetchMonthOnline(latitude, longitude float64, method int, month time.Time) ([]DaySchedule, error) {
+        url := fmt.Sprintf("http://api.aladhan.com/v1/calendar/%d/%d?latitude=%f&longitude=%f&method=%d",
+                month.Year(), int(month.Month()), latitude, longitude, method)
+
+        resp, err := http.Get(url)
+        if err != nil {
+                return nil, fmt.Errorf("calendar HTTP request failed: %w", err)
+        }
+        defer resp.Body.Close()
+
+        body, err := io.ReadAll(resp.Body)
+        if err != nil {
+                return nil, fmt.Errorf("failed to read calendar response body: %w", err)
+        }
+        if resp.StatusCode != http.StatusOK {
+                return nil, fmt.Errorf("calendar HTTP request returned status: %s, body: %s", resp.Status, string(body))
+        }
+
+        var cal calendarResponse
+        if err := json.Unmarshal(body, &cal); err != nil {
+                return nil, fmt.Errorf("failed to decode calendar JSON: %w", err)
+        }
+        if cal.Code != 200 {
+                return nil, fmt.Errorf("calendar API returned code: %d", cal.Code)
+        }
+
+        schedule := make([]DaySchedule, 0, len(cal.Data))
+        for _, d := range cal.Data {
+                date, err := time.ParseInLocation("02-01-2006", d.Date.Gregorian.Date, month.Location())
+                if err != nil {
+                        return nil, fmt.Errorf("parsing calendar date %q: %w", d.Date.Gregorian.Date, err)
+                }
+                schedule = append(schedule, DaySchedule{Date: date, Hijri: d.Date.Hijri.Readable, Timings: d.Timings})
+        }
+        return schedule, nil
+}
+
+// writeSchedule renders schedule in the requested format.
+func writeSchedule(w io.Writer, format string, schedule []DaySchedule, eventDuration, reminder time.Duration) error {
+        switch format {
+        case "", "text":
+                return writeText(w, schedule)
+        case "json":
+                return writeJSON(w, schedule)
+        case "ics":
+                return writeICS(w, schedule, eventDuration, reminder)
+        default:
+                return fmt.Errorf("unknown -format %q", format)
+        }
+}
+
+// prayerOrder is a day's prayers in their canonical chronological sequence.
+// orderedTimings walks it in order (rather than comparing raw clock times)
+// so that Isha/Midnight at high latitude, which can land after local
+// midnight, are recognized as happening after Maghrib instead of being
+// mistaken for the earliest event of the day.
+var prayerOrder = []string{"Imsak", "Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha", "Midnight"}
+
+// namedTime pairs a prayer name with its resolved absolute instant.
+type namedTime struct {
+        Name string
+        At   time.Time
+}
+
+// orderedTimings resolves each entry of timings to an absolute time.Time in
+// loc, in chronological order. Known prayer names are walked in
+// prayerOrder, rolling the calendar day forward whenever the next prayer's
+// clock time isn't after the previous one's - the same day-rollover
+// prayer.Compute itself applies to Isha/Midnight, needed again here because
+// timings only carries "HH:MM" strings, not the time.Time values Compute
+// produced. Any key not in prayerOrder (Aladhan's API returns a few extras
+// such as Sunset, Firstthird and Lastthird) is appended afterward in
+// same-day, time-of-day order.
+func orderedTimings(date time.Time, timings map[string]string, loc *time.Location) []namedTime {
+        out := make([]namedTime, 0, len(timings))
+        known := make(map[string]bool, len(prayerOrder))
+        day := date
+
+        for _, name := range prayerOrder {
+                known[name] = true
+                raw, ok := timings[name]
+                if !ok {
+                        continue
+                }
+                t, err := parseTime(raw)
+                if err != nil {
+                        continue
+                }
+                at := time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+                if len(out) > 0 && !at.After(out[len(out)-1].At) {
+                        day = day.AddDate(0, 0, 1)
+                        at = time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+                }
+                out = append(out, namedTime{Name: name, At: at})
+        }
+
+        var extra []namedTime
+        for name, raw := range timings {
+                if known[name] {
+                        continue
+                }
+                t, err := parseTime(raw)
+                if err != nil {
+                        continue
+                }
+                extra = append(extra, namedTime{
+                        Name: name,
+                        At:   time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, loc),
+                })
+        }
+        sort.Slice(extra, func(i, j int) bool { return extra[i].At.Before(extra[j].At) })
+
+        return append(out, extra...)
+}
+
+func writeText(w io.Writer, schedule []DaySchedule) error {
+        for i, d := range schedule {
+                if i > 0 {
+                        fmt.Fprintln(w)
+                }
+                fmt.Fprintf(w, "Date: %s\n", d.Date.Format("02 Jan 2006"))
+                fmt.Fprintln(w, "----------------------")
+                for _, nt := range orderedTimings(d.Date, d.Timings, d.Date.Location()) {
+                        fmt.Fprintf(w, "%-8s \t: %s\n", nt.Name, d.Timings[nt.Name])
+                }
+        }
+        return nil
+}
+
+type jsonPrayer struct {
+        Name string `json:"name"`
+        Time string `json:"time"`
+        Unix int64  `json:"unix"`
+}
+
+type jsonDay struct {
+        Date    string       `json:"date"`
+        Hijri   string       `json:"hijri"`
+        Timings []jsonPrayer `json:"timings"`
+}
+
+func writeJSON(w io.Writer, schedule []DaySchedule) error {
+        days := make([]jsonDay, 0, len(schedule))
+        for _, d := range schedule {
+                ordered := orderedTimings(d.Date, d.Timings, d.Date.Location())
+                timings := make([]jsonPrayer, 0, len(ordered))
+                for _, nt := range ordered {
+                        timings = append(timings, jsonPrayer{Name: nt.Name, Time: d.Timings[nt.Name], Unix: nt.At.Unix()})
+                }
+                days = append(days, jsonDay{Date: d.Date.Format("2006-01-02"), Hijri: d.Hijri, Timings: timings})
+        }
+
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(days)
+}
+
+// icsZoneID returns the IANA zone name to publish as DTSTART's TZID, or ""
+// if none is known. time.Local.String() returns the literal string "Local"
+// rather than an IANA name whenever $TZ is unset (the common case on
+// servers/containers), and Google/Apple Calendar don't recognize "Local" as
+// a TZID; check $TZ explicitly first, since it's the one reliable source of
+// an actual zone name for time.Local, falling back to loc's own name for
+// a loc that already carries a real IANA zone name.
+func icsZoneID(loc *time.Location) string {
+        if tz := os.Getenv("TZ"); tz != "" {
+                return tz
+        }
+        if name := loc.String(); name != "" && name != "Local" {
+                return name
+        }
+        return ""
+}
+
+// writeICS renders schedule as an RFC 5545 calendar, one VEVENT per
+// prayer, with a VALARM firing reminder before each. DTSTART is written
+// with a TZID when one can be resolved to an IANA name (see icsZoneID); to
+// be safely importable by Google/Apple Calendar, it falls back to a
+// floating local time with no TZID parameter at all rather than publish an
+// unrecognized name such as "Local".
+func writeICS(w io.Writer, schedule []DaySchedule, eventDuration, reminder time.Duration) error {
+        loc := time.Local
+        if len(schedule) > 0 {
+                loc = schedule[0].Date.Location()
+        }
+        zoneID := icsZoneID(loc)
+
+        var b strings.Builder
+        b.WriteString("BEGIN:VCALENDAR\r\n")
+        b.WriteString("VERSION:2.0\r\n")
+        b.WriteString("PRODID:-//athan-cli//Prayer Schedule//EN\r\n")
+        b.WriteString("CALSCALE:GREGORIAN\r\n")
+
+        stamp := time.Now().UTC().Format("20060102T150405Z")
+        for _, d := range schedule {
+                for _, nt := range orderedTimings(d.Date, d.Timings, loc) {
+                        start := nt.At
+
+                        b.WriteString("BEGIN:VEVENT\r\n")
+                        fmt.Fprintf(&b, "UID:%s-%s@athan-cli\r\n", start.Format("20060102T150405"), strings.ToLower(nt.Name))
+                        fmt.Fprintf(&b, "DTSTAMP:%s\r\n", stamp)
+                        if zoneID != "" {
+                                fmt.Fprintf(&b, "DTSTART;TZID=%s:%s\r\n", zoneID, start.Format("20060102T150405"))
+                        } else {
+                                fmt.Fprintf(&b, "DTSTART:%s\r\n", start.Format("20060102T150405"))
+                        }
+                        fmt.Fprintf(&b, "DURATION:PT%dM\r\n", int(eventDuration.Minutes()))
+                        fmt.Fprintf(&b, "SUMMARY:%s\r\n", nt.Name)
+                        b.WriteString("BEGIN:VALARM\r\n")
+                        b.WriteString("ACTION:DISPLAY\r\n")
+                        fmt.Fprintf(&b, "DESCRIPTION:%s\r\n", nt.Name)
+                        fmt.Fprintf(&b, "TRIGGER:-PT%dM\r\n", int(reminder.Minutes()))
+                        b.WriteString("END:VALARM\r\n")
+                        b.WriteString("END:VEVENT\r\n")
+                }
+        }
+        b.WriteString("END:VCALENDAR\r\n")
+
+        _, err := io.WriteString(w, b.String())
+        return err
+}
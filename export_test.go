@@ -0,0 +1,150 @@
+package main
+
+import (
+        "bytes"
+        "encoding/json"
+        "strings"
+        "testing"
+        "time"
+)
+
+func TestDaysIn(t *testing.T) {
+        cases := []struct {
+                year  int
+                month time.Month
+                want  int
+        }{
+                {2026, time.February, 28},
+                {2024, time.February, 29}, // leap year
+                {2026, time.January, 31},
+                {2026, time.April, 30},
+        }
+        for _, c := range cases {
+                if got := daysIn(c.year, c.month); got != c.want {
+                        t.Errorf("daysIn(%d, %s) = %d, want %d", c.year, c.month, got, c.want)
+                }
+        }
+}
+
+func TestOrderedTimings(t *testing.T) {
+        date := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+        timings := map[string]string{
+                "Isha":  "19:20",
+                "Fajr":  "05:31",
+                "Dhuhr": "12:21",
+        }
+        got := orderedTimings(date, timings, time.UTC)
+        want := []string{"Fajr", "Dhuhr", "Isha"}
+        if len(got) != len(want) {
+                t.Fatalf("orderedTimings = %v, want names %v", got, want)
+        }
+        for i := range want {
+                if got[i].Name != want[i] {
+                        t.Errorf("orderedTimings = %v, want names %v", got, want)
+                }
+        }
+}
+
+// TestOrderedTimingsRollsIshaToNextDay is the export-side counterpart to
+// prayer.Compute's own day-rollover fix: timings only carries "HH:MM"
+// strings, so orderedTimings has to detect the same midnight crossing
+// independently when it rebuilds absolute timestamps for JSON/ICS.
+func TestOrderedTimingsRollsIshaToNextDay(t *testing.T) {
+        date := time.Date(2026, 6, 21, 0, 0, 0, 0, time.UTC)
+        timings := map[string]string{
+                "Fajr":    "02:19",
+                "Maghrib": "22:42",
+                "Isha":    "00:10",
+        }
+        ordered := orderedTimings(date, timings, time.UTC)
+
+        byName := make(map[string]time.Time, len(ordered))
+        for _, nt := range ordered {
+                byName[nt.Name] = nt.At
+        }
+
+        if !byName["Isha"].After(byName["Maghrib"]) {
+                t.Errorf("Isha (%s) is not after Maghrib (%s)", byName["Isha"], byName["Maghrib"])
+        }
+        if got, want := byName["Isha"].Day(), date.Day()+1; got != want {
+                t.Errorf("Isha landed on day %d, want day %d", got, want)
+        }
+}
+
+func TestIcsZoneID(t *testing.T) {
+        t.Setenv("TZ", "")
+        if got := icsZoneID(time.Local); got != "" {
+                t.Errorf("icsZoneID(time.Local) with no $TZ = %q, want \"\" (floating time, not the literal \"Local\")", got)
+        }
+
+        loc, err := time.LoadLocation("Europe/Oslo")
+        if err != nil {
+                t.Skipf("tzdata for Europe/Oslo not available: %v", err)
+        }
+        if got := icsZoneID(loc); got != "Europe/Oslo" {
+                t.Errorf("icsZoneID(Europe/Oslo) = %q, want %q", got, "Europe/Oslo")
+        }
+}
+
+func dayFixture() DaySchedule {
+        loc := time.UTC
+        return DaySchedule{
+                Date:  time.Date(2026, 1, 15, 0, 0, 0, 0, loc),
+                Hijri: "25 Rajab 1447",
+                Timings: map[string]string{
+                        "Fajr":  "05:31",
+                        "Dhuhr": "12:21",
+                        "Isha":  "19:20",
+                },
+        }
+}
+
+func TestWriteJSONShape(t *testing.T) {
+        var buf bytes.Buffer
+        if err := writeJSON(&buf, []DaySchedule{dayFixture()}); err != nil {
+                t.Fatalf("writeJSON: %v", err)
+        }
+
+        var days []jsonDay
+        if err := json.Unmarshal(buf.Bytes(), &days); err != nil {
+                t.Fatalf("decoding writeJSON output: %v", err)
+        }
+        if len(days) != 1 {
+                t.Fatalf("got %d days, want 1", len(days))
+        }
+        if days[0].Date != "2026-01-15" || days[0].Hijri != "25 Rajab 1447" {
+                t.Errorf("day = %+v", days[0])
+        }
+        if len(days[0].Timings) != 3 || days[0].Timings[0].Name != "Fajr" {
+                t.Errorf("timings = %+v, want Fajr first", days[0].Timings)
+        }
+}
+
+func TestWriteICSShape(t *testing.T) {
+        var buf bytes.Buffer
+        err := writeICS(&buf, []DaySchedule{dayFixture()}, 10*time.Minute, 15*time.Minute)
+        if err != nil {
+                t.Fatalf("writeICS: %v", err)
+        }
+        out := buf.String()
+
+        for _, want := range []string{
+                "BEGIN:VCALENDAR", "END:VCALENDAR",
+                "BEGIN:VEVENT", "END:VEVENT",
+                "SUMMARY:Fajr", "DURATION:PT10M", "TRIGGER:-PT15M",
+        } {
+                if !strings.Contains(out, want) {
+                        t.Errorf("writeICS output missing %q", want)
+                }
+        }
+        if strings.Count(out, "BEGIN:VEVENT") != 3 {
+                t.Errorf("writeICS produced %d VEVENTs, want 3 (one per timing)", strings.Count(out, "BEGIN:VEVENT"))
+        }
+}
+
+func TestWriteScheduleUnknownFormat(t *testing.T) {
+        var buf bytes.Buffer
+        if err := writeSchedule(&buf, "yaml", nil, 0, 0); err == nil {
+                t.Error("writeSchedule: expected an error for an unknown format")
+        }
+}
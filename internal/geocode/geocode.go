@@ -0,0 +1,284 @@
+// Package geocode resolves a free-form city name to coordinates through a
+// pluggable set of providers, backed by a persistent on-disk cache so
+// repeated or rate-limited lookups don't need the network.
+package geocode
+
+import (
+        "crypto/sha1"
+        "encoding/hex"
+        "encoding/json"
+        "fmt"
+        "io"
+        "net/http"
+        "net/url"
+        "os"
+        "path/filepath"
+        "regexp"
+        "strings"
+        "time"
+)
+
+// Result is a single candidate location returned by a Geocoder.
+type Result struct {
+        Name string  `json:"name"`
+        Lat  float64 `json:"lat"`
+        Lng  float64 `json:"lng"`
+}
+
+// Geocoder resolves a city name to one or more candidate locations. More
+// than one Result is returned when the provider can't disambiguate the
+// query on its own.
+type Geocoder interface {
+        Geocode(city string) ([]Result, error)
+}
+
+// New returns the Geocoder registered under name: "google", "nominatim" or
+// "owm".
+func New(name string) (Geocoder, error) {
+        switch name {
+        case "google", "":
+                return googleGeocoder{}, nil
+        case "nominatim":
+                return nominatimGeocoder{}, nil
+        case "owm":
+                return owmGeocoder{}, nil
+        default:
+                return nil, fmt.Errorf("geocode: unknown provider %q", name)
+        }
+}
+
+// googleGeocoder uses the Google Maps Geocoding API and requires
+// GOOGLE_MAPS_API_KEY.
+type googleGeocoder struct{}
+
+func (googleGeocoder) Geocode(city string) ([]Result, error) {
+        apiKey := os.Getenv("GOOGLE_MAPS_API_KEY")
+        if apiKey == "" {
+                return nil, fmt.Errorf("geocode: GOOGLE_MAPS_API_KEY environment variable not set")
+        }
+
+        u := fmt.Sprintf("https://maps.googleapis.com/maps/api/geocode/json?address=%s&key=%s", url.QueryEscape(city), apiKey)
+        var resp struct {
+                Results []struct {
+                        FormattedAddress string `json:"formatted_address"`
+                        Geometry         struct {
+                                Location struct {
+                                        Lat float64 `json:"lat"`
+                                        Lng float64 `json:"lng"`
+                                } `json:"location"`
+                        } `json:"geometry"`
+                } `json:"results"`
+        }
+        if err := getJSON(u, &resp); err != nil {
+                return nil, err
+        }
+
+        results := make([]Result, 0, len(resp.Results))
+        for _, r := range resp.Results {
+                results = append(results, Result{Name: r.FormattedAddress, Lat: r.Geometry.Location.Lat, Lng: r.Geometry.Location.Lng})
+        }
+        if len(results) == 0 {
+                return nil, fmt.Errorf("geocode: city not found")
+        }
+        return results, nil
+}
+
+// nominatimGeocoder uses OpenStreetMap's free Nominatim search API.
+type nominatimGeocoder struct{}
+
+func (nominatimGeocoder) Geocode(city string) ([]Result, error) {
+        u := fmt.Sprintf("https://nominatim.openstreetmap.org/search?format=json&q=%s", url.QueryEscape(city))
+        var resp []struct {
+                DisplayName string `json:"display_name"`
+                Lat         string `json:"lat"`
+                Lon         string `json:"lon"`
+        }
+        if err := getJSON(u, &resp); err != nil {
+                return nil, err
+        }
+
+        results := make([]Result, 0, len(resp))
+        for _, r := range resp {
+                var lat, lng float64
+                if _, err := fmt.Sscanf(r.Lat, "%f", &lat); err != nil {
+                        continue
+                }
+                if _, err := fmt.Sscanf(r.Lon, "%f", &lng); err != nil {
+                        continue
+                }
+                results = append(results, Result{Name: r.DisplayName, Lat: lat, Lng: lng})
+        }
+        if len(results) == 0 {
+                return nil, fmt.Errorf("geocode: city not found")
+        }
+        return results, nil
+}
+
+// owmGeocoder uses OpenWeatherMap's /geo/1.0/direct endpoint and requires
+// OPENWEATHERMAP_API_KEY.
+type owmGeocoder struct{}
+
+func (owmGeocoder) Geocode(city string) ([]Result, error) {
+        apiKey := os.Getenv("OPENWEATHERMAP_API_KEY")
+        if apiKey == "" {
+                return nil, fmt.Errorf("geocode: OPENWEATHERMAP_API_KEY environment variable not set")
+        }
+
+        u := fmt.Sprintf("https://api.openweathermap.org/geo/1.0/direct?q=%s&limit=5&appid=%s", url.QueryEscape(city), apiKey)
+        var resp []struct {
+                Name    string  `json:"name"`
+                State   string  `json:"state"`
+                Country string  `json:"country"`
+                Lat     float64 `json:"lat"`
+                Lon     float64 `json:"lon"`
+        }
+        if err := getJSON(u, &resp); err != nil {
+                return nil, err
+        }
+
+        results := make([]Result, 0, len(resp))
+        for _, r := range resp {
+                name := r.Name
+                if r.State != "" {
+                        name += ", " + r.State
+                }
+                if r.Country != "" {
+                        name += ", " + r.Country
+                }
+                results = append(results, Result{Name: name, Lat: r.Lat, Lng: r.Lon})
+        }
+        if len(results) == 0 {
+                return nil, fmt.Errorf("geocode: city not found")
+        }
+        return results, nil
+}
+
+func getJSON(u string, out any) error {
+        req, err := http.NewRequest(http.MethodGet, u, nil)
+        if err != nil {
+                return fmt.Errorf("geocode: building request: %w", err)
+        }
+        req.Header.Set("User-Agent", "athan-cli")
+
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+                return fmt.Errorf("geocode: request failed: %w", err)
+        }
+        defer resp.Body.Close()
+
+        body, err := io.ReadAll(resp.Body)
+        if err != nil {
+                return fmt.Errorf("geocode: reading response: %w", err)
+        }
+        if resp.StatusCode != http.StatusOK {
+                return fmt.Errorf("geocode: request returned status %s: %s", resp.Status, string(body))
+        }
+        if err := json.Unmarshal(body, out); err != nil {
+                return fmt.Errorf("geocode: decoding response: %w", err)
+        }
+        return nil
+}
+
+var whitespaceRun = regexp.MustCompile(`\s+`)
+
+// normalizeKey collapses whitespace and case so that equivalent queries
+// ("Mecca", "  mecca ", "MECCA") share one cache entry, then hashes the
+// result so the on-disk cache file doesn't leak raw query text as keys.
+func normalizeKey(city string) string {
+        normalized := whitespaceRun.ReplaceAllString(strings.ToLower(strings.TrimSpace(city)), " ")
+        sum := sha1.Sum([]byte(normalized))
+        return hex.EncodeToString(sum[:])
+}
+
+type cacheEntry struct {
+        Results []Result  `json:"results"`
+        Cached  time.Time `json:"cached"`
+}
+
+// Cache is a persistent JSON cache of geocoding results, keyed by a
+// normalized, hashed version of the queried city name.
+type Cache struct {
+        path    string
+        ttl     time.Duration
+        entries map[string]cacheEntry
+}
+
+// DefaultCachePath returns athan-cli/geocode.json under os.UserCacheDir:
+// $XDG_CACHE_HOME (or ~/.cache when it isn't set) on Unix systems other
+// than macOS, and the platform's default cache directory elsewhere.
+func DefaultCachePath() (string, error) {
+        dir, err := os.UserCacheDir()
+        if err != nil {
+                return "", fmt.Errorf("geocode: resolving cache directory: %w", err)
+        }
+        return filepath.Join(dir, "athan-cli", "geocode.json"), nil
+}
+
+// OpenCache loads the cache file at path, treating a missing file as an
+// empty cache. ttl controls how long an entry remains valid in Lookup.
+func OpenCache(path string, ttl time.Duration) (*Cache, error) {
+        c := &Cache{path: path, ttl: ttl, entries: map[string]cacheEntry{}}
+
+        data, err := os.ReadFile(path)
+        if err != nil {
+                if os.IsNotExist(err) {
+                        return c, nil
+                }
+                return nil, fmt.Errorf("geocode: reading cache: %w", err)
+        }
+        if err := json.Unmarshal(data, &c.entries); err != nil {
+                return nil, fmt.Errorf("geocode: decoding cache: %w", err)
+        }
+        return c, nil
+}
+
+// Lookup returns the cached results for city if present and not expired.
+func (c *Cache) Lookup(city string) ([]Result, bool) {
+        entry, ok := c.entries[normalizeKey(city)]
+        if !ok {
+                return nil, false
+        }
+        if c.ttl > 0 && time.Since(entry.Cached) > c.ttl {
+                return nil, false
+        }
+        return entry.Results, true
+}
+
+// Store records results for city and persists the cache to disk.
+func (c *Cache) Store(city string, results []Result) error {
+        c.entries[normalizeKey(city)] = cacheEntry{Results: results, Cached: time.Now()}
+
+        if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+                return fmt.Errorf("geocode: creating cache directory: %w", err)
+        }
+        data, err := json.MarshalIndent(c.entries, "", "  ")
+        if err != nil {
+                return fmt.Errorf("geocode: encoding cache: %w", err)
+        }
+        if err := os.WriteFile(c.path, data, 0o644); err != nil {
+                return fmt.Errorf("geocode: writing cache: %w", err)
+        }
+        return nil
+}
+
+// Lookup resolves city through geocoder, transparently serving a fresh
+// cache entry when one exists and storing the result otherwise.
+func Lookup(geocoder Geocoder, cache *Cache, city string) ([]Result, error) {
+        if cache != nil {
+                if results, ok := cache.Lookup(city); ok {
+                        return results, nil
+                }
+        }
+
+        results, err := geocoder.Geocode(city)
+        if err != nil {
+                return nil, err
+        }
+
+        if cache != nil {
+                if err := cache.Store(city, results); err != nil {
+                        return nil, err
+                }
+        }
+        return results, nil
+}
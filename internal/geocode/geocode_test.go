@@ -0,0 +1,102 @@
+package geocode
+
+import (
+        "path/filepath"
+        "testing"
+        "time"
+)
+
+func TestNormalizeKeyCollapsesWhitespaceAndCase(t *testing.T) {
+        want := normalizeKey("mecca")
+        for _, variant := range []string{"Mecca", "  mecca ", "MECCA", "mecca"} {
+                if got := normalizeKey(variant); got != want {
+                        t.Errorf("normalizeKey(%q) = %q, want %q", variant, got, want)
+                }
+        }
+        if normalizeKey("Mecca") == normalizeKey("Medina") {
+                t.Error("normalizeKey produced the same key for different cities")
+        }
+}
+
+func TestNewUnknownProvider(t *testing.T) {
+        if _, err := New("bogus"); err == nil {
+                t.Error("New: expected an error for an unknown provider")
+        }
+}
+
+func TestCacheStoreAndLookupRoundTrip(t *testing.T) {
+        path := filepath.Join(t.TempDir(), "geocode.json")
+        cache, err := OpenCache(path, time.Hour)
+        if err != nil {
+                t.Fatalf("OpenCache: %v", err)
+        }
+
+        if _, ok := cache.Lookup("Cairo"); ok {
+                t.Fatal("Lookup found an entry in an empty cache")
+        }
+
+        want := []Result{{Name: "Cairo, Egypt", Lat: 30.0444, Lng: 31.2357}}
+        if err := cache.Store("Cairo", want); err != nil {
+                t.Fatalf("Store: %v", err)
+        }
+
+        got, ok := cache.Lookup("  CAIRO ")
+        if !ok {
+                t.Fatal("Lookup: expected a hit after Store, using a differently-cased/spaced query")
+        }
+        if len(got) != 1 || got[0] != want[0] {
+                t.Errorf("Lookup = %v, want %v", got, want)
+        }
+
+        reopened, err := OpenCache(path, time.Hour)
+        if err != nil {
+                t.Fatalf("OpenCache (reopen): %v", err)
+        }
+        if _, ok := reopened.Lookup("Cairo"); !ok {
+                t.Error("Lookup after reopening from disk: expected a hit")
+        }
+}
+
+func TestCacheLookupExpires(t *testing.T) {
+        path := filepath.Join(t.TempDir(), "geocode.json")
+        cache, err := OpenCache(path, time.Nanosecond)
+        if err != nil {
+                t.Fatalf("OpenCache: %v", err)
+        }
+        if err := cache.Store("Jakarta", []Result{{Name: "Jakarta", Lat: -6.2, Lng: 106.8}}); err != nil {
+                t.Fatalf("Store: %v", err)
+        }
+
+        time.Sleep(time.Millisecond)
+        if _, ok := cache.Lookup("Jakarta"); ok {
+                t.Error("Lookup: expected a miss once the entry's ttl has elapsed")
+        }
+}
+
+type stubGeocoder struct {
+        results []Result
+        calls   int
+}
+
+func (s *stubGeocoder) Geocode(city string) ([]Result, error) {
+        s.calls++
+        return s.results, nil
+}
+
+func TestLookupUsesCacheBeforeGeocoder(t *testing.T) {
+        cache, err := OpenCache(filepath.Join(t.TempDir(), "geocode.json"), time.Hour)
+        if err != nil {
+                t.Fatalf("OpenCache: %v", err)
+        }
+        stub := &stubGeocoder{results: []Result{{Name: "London", Lat: 51.5, Lng: -0.12}}}
+
+        if _, err := Lookup(stub, cache, "London"); err != nil {
+                t.Fatalf("Lookup: %v", err)
+        }
+        if _, err := Lookup(stub, cache, "London"); err != nil {
+                t.Fatalf("Lookup (second call): %v", err)
+        }
+        if stub.calls != 1 {
+                t.Errorf("Geocoder called %d times, want 1 (second Lookup should hit the cache)", stub.calls)
+        }
+}
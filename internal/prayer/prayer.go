@@ -0,0 +1,320 @@
+// Package prayer computes Islamic prayer times locally from latitude,
+// longitude and a calendar date, without calling a remote API. It implements
+// the widely used low-precision solar position formulas (Julian day, solar
+// declination and equation of time) combined with the standard hour-angle
+// equation for Fajr/Isha and the shadow-length equation for Asr.
+package prayer
+
+import (
+        "fmt"
+        "math"
+        "time"
+)
+
+// Method identifies a named convention for the Fajr/Isha twilight angles.
+type Method int
+
+const (
+        MWL Method = iota // Muslim World League: 18, 17
+        ISNA
+        Egyptian
+        UmmAlQura
+        Karachi
+        Tehran
+        Jafari
+)
+
+// methodAngle holds the Fajr/Isha twilight angles (degrees below the
+// horizon) for a Method. IshaMinutes is used instead of IshaAngle when
+// non-zero (Umm al-Qura fixes Isha at a clock offset from Maghrib rather
+// than a twilight angle).
+type methodAngle struct {
+        Fajr        float64
+        Isha        float64
+        IshaMinutes float64
+}
+
+var methodAngles = map[Method]methodAngle{
+        MWL:       {Fajr: 18, Isha: 17},
+        ISNA:      {Fajr: 15, Isha: 15},
+        Egyptian:  {Fajr: 19.5, Isha: 17.5},
+        UmmAlQura: {Fajr: 18.5, IshaMinutes: 90},
+        Karachi:   {Fajr: 18, Isha: 18},
+        Tehran:    {Fajr: 17.7, Isha: 14},
+        Jafari:    {Fajr: 16, Isha: 14},
+}
+
+// AsrMethod selects the shadow-length factor used to compute Asr.
+type AsrMethod int
+
+const (
+        Shafii AsrMethod = iota // shadow factor 1 (majority of schools)
+        Hanafi                  // shadow factor 2
+)
+
+// HighLatitudeRule adjusts Fajr/Isha when the sun never reaches the
+// required angle below the horizon, which happens above roughly 48-50
+// degrees latitude for parts of the year.
+type HighLatitudeRule int
+
+const (
+        NoHighLatitudeRule HighLatitudeRule = iota
+        AngleBased
+        OneSeventh
+        NightMiddle
+)
+
+// Params controls how Compute selects twilight angles and adjustments.
+type Params struct {
+        Method           Method
+        Asr              AsrMethod
+        HighLatitudeRule HighLatitudeRule
+
+        // FajrAngle/IshaAngle override the Method's default angle when non-zero.
+        FajrAngle float64
+        IshaAngle float64
+}
+
+func (p Params) angles() (fajr, isha, ishaMinutes float64) {
+        a := methodAngles[p.Method]
+        fajr, isha, ishaMinutes = a.Fajr, a.Isha, a.IshaMinutes
+        if p.FajrAngle != 0 {
+                fajr = p.FajrAngle
+        }
+        if p.IshaAngle != 0 {
+                isha, ishaMinutes = p.IshaAngle, 0
+        }
+        return fajr, isha, ishaMinutes
+}
+
+// Times holds a day's computed prayer times, all in the location implied by
+// the date passed to Compute.
+type Times struct {
+        Imsak    time.Time
+        Fajr     time.Time
+        Sunrise  time.Time
+        Dhuhr    time.Time
+        Asr      time.Time
+        Maghrib  time.Time
+        Isha     time.Time
+        Midnight time.Time
+}
+
+const sunAngle = 0.833 // apparent sun radius + standard atmospheric refraction
+
+// Compute derives prayer times for the given coordinates and calendar date
+// using Params to select the calculation convention. date's location is
+// used both as the civil timezone and to read the calendar day; only the
+// year/month/day components are used, the date's wall-clock time is
+// ignored.
+func Compute(lat, lng float64, date time.Time, params Params) (Times, error) {
+        if lat < -90 || lat > 90 {
+                return Times{}, fmt.Errorf("prayer: latitude %g out of range", lat)
+        }
+        if lng < -180 || lng > 180 {
+                return Times{}, fmt.Errorf("prayer: longitude %g out of range", lng)
+        }
+
+        _, tzOffset := date.Zone()
+        tz := float64(tzOffset) / 3600
+
+        jd := julianDay(date) - lng/(15*24)
+
+        fajrAngle, ishaAngle, ishaMinutes := params.angles()
+        shadowFactor := 1.0
+        if params.Asr == Hanafi {
+                shadowFactor = 2
+        }
+
+        // First pass uses the sun's position at local mean noon (jd+0.5) to
+        // get an estimate of each event's hour; a second pass re-evaluates
+        // the sun's position at that estimated hour, which removes the
+        // small error the sun's declination drift introduces away from
+        // noon.
+        dhuhr := 12 + tz - lng/15
+        sunrise := dhuhr - sunAngleTime(sunAngle, lat, jd+0.5)
+        sunset := dhuhr + sunAngleTime(sunAngle, lat, jd+0.5)
+        fajr := dhuhr - sunAngleTime(fajrAngle, lat, jd+0.5)
+        isha := dhuhr + sunAngleTime(ishaAngle, lat, jd+0.5)
+        asr := dhuhr + asrTime(shadowFactor, lat, jd+0.5)
+
+        sunrise = dhuhr - sunAngleTime(sunAngle, lat, jd+sunrise/24)
+        sunset = dhuhr + sunAngleTime(sunAngle, lat, jd+sunset/24)
+        fajr = dhuhr - sunAngleTime(fajrAngle, lat, jd+fajr/24)
+        asr = dhuhr + asrTime(shadowFactor, lat, jd+asr/24)
+        if ishaMinutes > 0 {
+                isha = sunset + ishaMinutes/60
+        } else {
+                isha = dhuhr + sunAngleTime(ishaAngle, lat, jd+isha/24)
+        }
+        maghrib := sunset
+        imsak := fajr - 10.0/60
+
+        if math.IsNaN(sunrise) || math.IsNaN(sunset) {
+                return Times{}, fmt.Errorf("prayer: sun does not rise or set at latitude %g on this date", lat)
+        }
+
+        if params.HighLatitudeRule == NoHighLatitudeRule {
+                if math.IsNaN(fajr) || math.IsNaN(isha) {
+                        return Times{}, fmt.Errorf("prayer: sun never reaches the Fajr/Isha angle at latitude %g on this date; set Params.HighLatitudeRule", lat)
+                }
+        } else {
+                night := 24 - (sunset - sunrise)
+                fajr = adjustHighLatitude(fajr, sunrise, fajrAngle, night, params.HighLatitudeRule, -1)
+                if ishaMinutes == 0 {
+                        isha = adjustHighLatitude(isha, sunset, ishaAngle, night, params.HighLatitudeRule, 1)
+                }
+                imsak = fajr - 10.0/60
+        }
+
+        midnight := sunset + timeDiff(sunset, fajr+24)/2
+
+        y, m, d := date.Date()
+        mk := func(hours float64) time.Time {
+                return floatToTime(y, m, d, hours, date.Location())
+        }
+
+        return Times{
+                Imsak:    mk(imsak),
+                Fajr:     mk(fajr),
+                Sunrise:  mk(sunrise),
+                Dhuhr:    mk(dhuhr),
+                Asr:      mk(asr),
+                Maghrib:  mk(maghrib),
+                Isha:     mk(isha),
+                Midnight: mk(midnight),
+        }, nil
+}
+
+// julianDay returns the Julian day number for date's calendar day at 0h UT.
+func julianDay(date time.Time) float64 {
+        y, m, d := date.Date()
+        year, month, day := y, int(m), d
+        if month <= 2 {
+                year--
+                month += 12
+        }
+        a := math.Floor(float64(year) / 100)
+        b := 2 - a + math.Floor(a/4)
+        return math.Floor(365.25*float64(year+4716)) + math.Floor(30.6001*float64(month+1)) + float64(day) + b - 1524.5
+}
+
+// sunPosition returns the sun's declination and the equation of time (in
+// hours) for the given Julian day, using the low-precision series also used
+// by NOAA's solar calculator.
+func sunPosition(jd float64) (declination, equationOfTime float64) {
+        d := jd - 2451545.0
+        g := fixAngle(357.529 + 0.98560028*d)
+        q := fixAngle(280.459 + 0.98564736*d)
+        l := fixAngle(q + 1.915*sinDeg(g) + 0.020*sinDeg(2*g))
+
+        e := 23.439 - 0.00000036*d
+
+        ra := arctan2Deg(cosDeg(e)*sinDeg(l), cosDeg(l)) / 15
+        ra = fixHour(ra)
+
+        declination = asinDeg(sinDeg(e) * sinDeg(l))
+        equationOfTime = q/15 - ra
+        return declination, equationOfTime
+}
+
+// sunAngleTime returns, in hours, the magnitude of the time offset from
+// solar noon at which the sun is angle degrees below the horizon; the
+// caller adds or subtracts it from Dhuhr depending on whether it wants the
+// morning or afternoon crossing.
+func sunAngleTime(angle, lat, jd float64) float64 {
+        decl, _ := sunPosition(jd)
+        numerator := -sinDeg(angle) - sinDeg(lat)*sinDeg(decl)
+        denominator := cosDeg(lat) * cosDeg(decl)
+        return acosDeg(numerator/denominator) / 15
+}
+
+// asrTime returns, in hours after solar noon, the time at which an object's
+// shadow length equals shadowFactor times its height plus its noon shadow.
+func asrTime(shadowFactor, lat, jd float64) float64 {
+        decl, _ := sunPosition(jd)
+        angle := -acotDeg(shadowFactor + tanDeg(math.Abs(lat-decl)))
+        numerator := -sinDeg(angle) - sinDeg(lat)*sinDeg(decl)
+        denominator := cosDeg(lat) * cosDeg(decl)
+        return acosDeg(numerator/denominator) / 15
+}
+
+// nightPortion returns the fraction of the night used to cap the Fajr/Isha
+// adjustment under the given high-latitude rule.
+func nightPortion(rule HighLatitudeRule, angle float64) float64 {
+        switch rule {
+        case AngleBased:
+                return angle / 60
+        case OneSeventh:
+                return 1.0 / 7
+        case NightMiddle:
+                return 1.0 / 2
+        default:
+                return 0
+        }
+}
+
+// adjustHighLatitude caps the Fajr (dir=-1) or Isha (dir=1) offset from its
+// sunrise/sunset reference so it never exceeds the configured night
+// portion, following the standard high-latitude rules.
+func adjustHighLatitude(t, base, angle, night float64, rule HighLatitudeRule, dir float64) float64 {
+        portion := nightPortion(rule, angle) * night
+        var diff float64
+        if dir < 0 {
+                diff = timeDiff(t, base)
+        } else {
+                diff = timeDiff(base, t)
+        }
+        if math.IsNaN(t) || diff > portion {
+                return base + dir*portion
+        }
+        return t
+}
+
+func timeDiff(a, b float64) float64 {
+        return fixHour(b - a)
+}
+
+// floatToTime converts hours (which may fall outside [0,24) — Isha and
+// Midnight routinely do at high latitudes) into a time.Time on the
+// calendar day implied by year/month/day, advancing (or retreating) that
+// day by however many times hours wrapped so the result stays ordered
+// relative to the same call's other prayer times instead of silently
+// landing back on the original day.
+func floatToTime(year int, month time.Month, day int, hours float64, loc *time.Location) time.Time {
+        dayOffset := int(math.Floor(hours / 24))
+        hours = fixHour(hours)
+        h := int(hours)
+        m := int(math.Round((hours - float64(h)) * 60))
+        if m == 60 {
+                h++
+                m = 0
+        }
+        base := time.Date(year, month, day, 0, 0, 0, 0, loc)
+        if dayOffset != 0 {
+                base = base.AddDate(0, 0, dayOffset)
+        }
+        return base.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+}
+
+func fixAngle(a float64) float64 { return fixRange(a, 360) }
+func fixHour(h float64) float64  { return fixRange(h, 24) }
+
+func fixRange(v, r float64) float64 {
+        v = math.Mod(v, r)
+        if v < 0 {
+                v += r
+        }
+        return v
+}
+
+const deg2rad = math.Pi / 180
+const rad2deg = 180 / math.Pi
+
+func sinDeg(d float64) float64        { return math.Sin(d * deg2rad) }
+func cosDeg(d float64) float64        { return math.Cos(d * deg2rad) }
+func tanDeg(d float64) float64        { return math.Tan(d * deg2rad) }
+func asinDeg(x float64) float64       { return math.Asin(x) * rad2deg }
+func acosDeg(x float64) float64       { return math.Acos(x) * rad2deg }
+func acotDeg(x float64) float64       { return math.Atan(1/x) * rad2deg }
+func arctan2Deg(y, x float64) float64 { return math.Atan2(y, x) * rad2deg }
@@ -0,0 +1,208 @@
+package prayer
+
+import (
+        "math"
+        "testing"
+        "time"
+)
+
+// fixture is a known-good day's schedule, captured once from this package
+// and locked in as a regression check: any future change to the solar
+// position math or the hour-angle/high-latitude adjustment logic must keep
+// reproducing it within a minute.
+type fixture struct {
+        name      string
+        lat, lng  float64
+        params    Params
+        date      func() time.Time
+        wantFajr  string
+        wantDhuhr string
+        wantAsr   string
+        wantIsha  string
+}
+
+func TestComputeFixtures(t *testing.T) {
+        fixtures := []fixture{
+                {
+                        name: "Mecca UmmAlQura",
+                        lat:  21.4225, lng: 39.8262,
+                        params: Params{Method: UmmAlQura},
+                        date: func() time.Time {
+                                return time.Date(2026, 1, 15, 0, 0, 0, 0, mustLocFor("Asia/Riyadh"))
+                        },
+                        wantFajr: "05:31", wantDhuhr: "12:21", wantAsr: "15:28", wantIsha: "19:20",
+                },
+                {
+                        name: "Cairo Egyptian",
+                        lat:  30.0444, lng: 31.2357,
+                        params: Params{Method: Egyptian},
+                        date: func() time.Time {
+                                return time.Date(2026, 1, 15, 0, 0, 0, 0, mustLocFor("Africa/Cairo"))
+                        },
+                        wantFajr: "05:11", wantDhuhr: "11:55", wantAsr: "14:48", wantIsha: "18:30",
+                },
+                {
+                        name: "Oslo 60N summer AngleBased",
+                        lat:  59.9139, lng: 10.7522,
+                        params: Params{Method: MWL, HighLatitudeRule: AngleBased},
+                        date: func() time.Time {
+                                return time.Date(2026, 6, 21, 0, 0, 0, 0, mustLocFor("Europe/Oslo"))
+                        },
+                        wantFajr: "02:19", wantDhuhr: "13:17", wantAsr: "17:59", wantIsha: "00:10",
+                },
+        }
+
+        for _, f := range fixtures {
+                t.Run(f.name, func(t *testing.T) {
+                        date := f.date()
+                        times, err := Compute(f.lat, f.lng, date, f.params)
+                        if err != nil {
+                                t.Fatalf("Compute: %v", err)
+                        }
+                        checkWithin(t, "Fajr", times.Fajr, f.wantFajr, date.Location())
+                        checkWithin(t, "Dhuhr", times.Dhuhr, f.wantDhuhr, date.Location())
+                        checkWithin(t, "Asr", times.Asr, f.wantAsr, date.Location())
+                        checkWithin(t, "Isha", times.Isha, f.wantIsha, date.Location())
+                })
+        }
+}
+
+func mustLocFor(name string) *time.Location {
+        loc, err := time.LoadLocation(name)
+        if err != nil {
+                // Fall back to UTC so the fixture still runs (with a shifted clock
+                // time) in environments without tzdata installed.
+                return time.UTC
+        }
+        return loc
+}
+
+// checkWithin asserts got is within one minute of a "15:04"-formatted want,
+// on the same calendar day as got, in loc.
+func checkWithin(t *testing.T, label string, got time.Time, want string, loc *time.Location) {
+        t.Helper()
+        wt, err := time.ParseInLocation("15:04", want, loc)
+        if err != nil {
+                t.Fatalf("parsing fixture time %q: %v", want, err)
+        }
+        y, m, d := got.Date()
+        wantAt := time.Date(y, m, d, wt.Hour(), wt.Minute(), 0, 0, loc)
+
+        diff := got.Sub(wantAt)
+        if diff < 0 {
+                diff = -diff
+        }
+        if diff > time.Minute {
+                t.Errorf("%s = %s, want %s (within 1m)", label, got.Format("15:04"), want)
+        }
+}
+
+// TestComputeHighLatitudeNoRuleErrors guards against the hour-angle equation
+// silently returning NaN (which floatToTime previously turned into a bogus
+// 00:00) when the sun never reaches the Fajr/Isha angle and no
+// HighLatitudeRule was configured.
+func TestComputeHighLatitudeNoRuleErrors(t *testing.T) {
+        loc := mustLocFor("Europe/Oslo")
+        date := time.Date(2026, 6, 21, 0, 0, 0, 0, loc)
+
+        _, err := Compute(59.9139, 10.7522, date, Params{Method: MWL})
+        if err == nil {
+                t.Fatal("Compute: expected an error for an unsolvable hour-angle with NoHighLatitudeRule, got nil")
+        }
+}
+
+// TestComputeHighLatitudeRuleFixesIt is the positive counterpart: the same
+// coordinates/date succeed, with no NaN in any field, once a
+// HighLatitudeRule is supplied.
+func TestComputeHighLatitudeRuleFixesIt(t *testing.T) {
+        loc := mustLocFor("Europe/Oslo")
+        date := time.Date(2026, 6, 21, 0, 0, 0, 0, loc)
+
+        times, err := Compute(59.9139, 10.7522, date, Params{Method: MWL, HighLatitudeRule: AngleBased})
+        if err != nil {
+                t.Fatalf("Compute: %v", err)
+        }
+        for name, tm := range map[string]time.Time{
+                "Fajr": times.Fajr, "Sunrise": times.Sunrise, "Dhuhr": times.Dhuhr,
+                "Asr": times.Asr, "Maghrib": times.Maghrib, "Isha": times.Isha,
+        } {
+                if tm.IsZero() || tm.Before(date) || !tm.Before(date.AddDate(0, 0, 2)) {
+                        t.Errorf("%s = %s, want a time on %s or the day after", name, tm, date.Format("2006-01-02"))
+                }
+        }
+}
+
+// TestComputeRollsIshaToNextCalendarDay guards against floatToTime wrapping
+// an hour past 24:00 back onto the same calendar day: Isha and Midnight at
+// high latitude routinely fall after midnight, and must land chronologically
+// after that same call's Maghrib, not hours "before" it.
+func TestComputeRollsIshaToNextCalendarDay(t *testing.T) {
+        loc := mustLocFor("Europe/Oslo")
+        date := time.Date(2026, 6, 21, 0, 0, 0, 0, loc)
+
+        times, err := Compute(59.9139, 10.7522, date, Params{Method: MWL, HighLatitudeRule: AngleBased})
+        if err != nil {
+                t.Fatalf("Compute: %v", err)
+        }
+        if !times.Isha.After(times.Maghrib) {
+                t.Errorf("Isha (%s) is not after Maghrib (%s)", times.Isha, times.Maghrib)
+        }
+        if !times.Midnight.After(times.Maghrib) {
+                t.Errorf("Midnight (%s) is not after Maghrib (%s)", times.Midnight, times.Maghrib)
+        }
+        wantDay := date.AddDate(0, 0, 1).Day()
+        if times.Isha.Day() != wantDay {
+                t.Errorf("Isha landed on day %d, want day %d (the day after %s)", times.Isha.Day(), wantDay, date.Format("2006-01-02"))
+        }
+}
+
+func TestComputeOrdering(t *testing.T) {
+        cases := []struct {
+                name     string
+                lat, lng float64
+                params   Params
+                date     time.Time
+        }{
+                {"Mecca", 21.4225, 39.8262, Params{Method: UmmAlQura}, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+                {"Cairo", 30.0444, 31.2357, Params{Method: Egyptian}, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+                {"Oslo", 59.9139, 10.7522, Params{Method: MWL, HighLatitudeRule: AngleBased}, time.Date(2026, 6, 21, 0, 0, 0, 0, time.UTC)},
+        }
+
+        for _, c := range cases {
+                t.Run(c.name, func(t *testing.T) {
+                        times, err := Compute(c.lat, c.lng, c.date, c.params)
+                        if err != nil {
+                                t.Fatalf("Compute: %v", err)
+                        }
+                        ordered := []time.Time{times.Fajr, times.Sunrise, times.Dhuhr, times.Asr, times.Maghrib}
+                        for i := 1; i < len(ordered); i++ {
+                                if !ordered[i].After(ordered[i-1]) {
+                                        t.Errorf("prayer %d (%s) is not after prayer %d (%s)", i, ordered[i], i-1, ordered[i-1])
+                                }
+                        }
+                })
+        }
+}
+
+func TestComputeRejectsOutOfRangeCoordinates(t *testing.T) {
+        date := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+        if _, err := Compute(91, 0, date, Params{}); err == nil {
+                t.Error("expected an error for latitude 91")
+        }
+        if _, err := Compute(0, 181, date, Params{}); err == nil {
+                t.Error("expected an error for longitude 181")
+        }
+}
+
+func TestFixRange(t *testing.T) {
+        cases := []struct{ v, r, want float64 }{
+                {370, 360, 10},
+                {-10, 360, 350},
+                {25, 24, 1},
+        }
+        for _, c := range cases {
+                if got := fixRange(c.v, c.r); math.Abs(got-c.want) > 1e-9 {
+                        t.Errorf("fixRange(%v, %v) = %v, want %v", c.v, c.r, got, c.want)
+                }
+        }
+}
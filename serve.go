@@ -0,0 +1,246 @@
+package main
+
+import (
+        "encoding/json"
+        "fmt"
+        "io"
+        "net/http"
+        "sort"
+        "strconv"
+        "strings"
+        "time"
+)
+
+// RenderOptions controls how Render formats a day's prayer schedule.
+type RenderOptions struct {
+        // ANSI enables terminal color codes, used for console clients hitting
+        // -serve over curl/wget/httpie.
+        ANSI bool
+}
+
+const (
+        ansiBold  = "\033[1m"
+        ansiGreen = "\033[32m"
+        ansiReset = "\033[0m"
+)
+
+// Render writes a day's prayer schedule and the countdown to the next
+// prayer to w. It backs both the one-shot CLI/daemon output and the
+// -serve HTTP handler's console-friendly response.
+func Render(w io.Writer, prayerTimes *PrayerTimes, opts RenderOptions) error {
+        header := "Islamic Prayer Times:"
+        if opts.ANSI {
+                header = ansiBold + header + ansiReset
+        }
+        fmt.Fprintln(w, header)
+        fmt.Fprintf(w, "Date: %s\n", prayerTimes.Data.Date.Readable)
+        fmt.Fprintln(w, "----------------------")
+
+        var prayers []Prayer
+        for prayerName, prayerTime := range prayerTimes.Data.Timings {
+                prayers = append(prayers, Prayer{Name: prayerName, Time: prayerTime})
+        }
+
+        sort.Slice(prayers, func(i, j int) bool {
+                timeI, _ := parseTime(prayers[i].Time)
+                timeJ, _ := parseTime(prayers[j].Time)
+
+                now := time.Now()
+                timeI = time.Date(now.Year(), now.Month(), now.Day(), timeI.Hour(), timeI.Minute(), 0, 0, now.Location())
+                timeJ = time.Date(now.Year(), now.Month(), now.Day(), timeJ.Hour(), timeJ.Minute(), 0, 0, now.Location())
+
+                return timeI.Before(timeJ)
+        })
+
+        for _, prayer := range prayers {
+                name := prayer.Name
+                if opts.ANSI {
+                        name = ansiGreen + name + ansiReset
+                }
+                fmt.Fprintf(w, "%-8s \t: %s\n", name, prayer.Time)
+        }
+
+        nextPrayerName, duration, err := calculateTimeUntilNextPrayer(prayerTimes.Data.Timings)
+        if err != nil {
+                return fmt.Errorf("calculating time until next prayer: %w", err)
+        }
+
+        hours := int(duration.Hours())
+        minutes := int(duration.Minutes()) % 60
+        seconds := int(duration.Seconds()) % 60
+
+        line := fmt.Sprintf("Time Until Next Prayer (%s): %02d:%02d:%02d", nextPrayerName, hours, minutes, seconds)
+        if opts.ANSI {
+                line = ansiBold + line + ansiReset
+        }
+        fmt.Fprintf(w, "\n%s\n", line)
+        return nil
+}
+
+var popularCities = []string{
+        "Mecca", "Medina", "Istanbul", "Cairo", "London",
+        "New York", "Jakarta", "Karachi", "Dubai",
+}
+
+// serveFetch resolves today's prayer times for (lat,lng), sharing the same
+// per-day cache the daemon uses so the upstream API (or offline
+// calculation) is hit at most once per location/day.
+func serveFetch(lat, lng float64, method int, offline bool) (*PrayerTimes, error) {
+        cfg := daemonConfig{Latitude: lat, Longitude: lng, Method: method, Offline: offline}
+        return cachedFetch(cfg, func() (*PrayerTimes, error) {
+                if offline {
+                        return computeOffline(lat, lng, method)
+                }
+                return getPrayerTimes(lat, lng, method, time.Now())
+        })
+}
+
+// warmPopularCities prefetches and caches today's schedule for a small set
+// of well-known cities, so the first real request for them is instant.
+func warmPopularCities(method int, offline bool, geocoder string) {
+        for _, city := range popularCities {
+                lat, lng, err := resolveCity(city, geocoder, nil, 0)
+                if err != nil {
+                        daemonLog.Warn("prefetch geocode failed", "city", city, "error", err)
+                        continue
+                }
+                if _, err := serveFetch(lat, lng, method, offline); err != nil {
+                        daemonLog.Warn("prefetch fetch failed", "city", city, "error", err)
+                }
+        }
+}
+
+// startPrefetchScheduler warms popular cities immediately, then on a fixed
+// schedule for the life of the process. Like runDaemon, this uses a plain
+// time.Ticker rather than a cron dependency (e.g. robfig/cron/v3): there is
+// only one job, on one fixed interval, and no go.sum in this tree to pin a
+// dependency in. A ticker is the direct expression of that; it would stop
+// being enough if this needed more than one interval or a cron expression
+// from config.
+func startPrefetchScheduler(method int, offline bool, geocoder string) {
+        warmPopularCities(method, offline, geocoder)
+        ticker := time.NewTicker(6 * time.Hour)
+        go func() {
+                for range ticker.C {
+                        warmPopularCities(method, offline, geocoder)
+                }
+        }()
+}
+
+// isConsoleClient reports whether userAgent looks like a terminal HTTP
+// client, the same content-negotiation trick wttr.in uses to decide
+// between an ANSI console reply and an HTML/JSON one.
+func isConsoleClient(userAgent string) bool {
+        ua := strings.ToLower(userAgent)
+        for _, tool := range []string{"curl", "wget", "httpie"} {
+                if strings.Contains(ua, tool) {
+                        return true
+                }
+        }
+        return false
+}
+
+func writeJSONResponse(w http.ResponseWriter, v any) {
+        w.Header().Set("Content-Type", "application/json")
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        _ = enc.Encode(v)
+}
+
+// parsePathLocation splits an HTTP path of the form "/<city>" or
+// "/<lat>,<lng>" into its component parts.
+func parsePathLocation(path string) (city string, lat, lng float64, hasLatLng bool) {
+        path = strings.TrimPrefix(path, "/")
+        if !strings.Contains(path, ",") {
+                return path, 0, 0, false
+        }
+
+        parts := strings.SplitN(path, ",", 2)
+        la, errA := strconv.ParseFloat(parts[0], 64)
+        lo, errB := strconv.ParseFloat(parts[1], 64)
+        if errA != nil || errB != nil {
+                return path, 0, 0, false
+        }
+        return "", la, lo, true
+}
+
+// serveMux builds the -serve HTTP routes: "/:city", "/:lat,:lng" and
+// "/next" (which reads ?city= or ?lat=&lng=).
+func serveMux(method int, offline bool, geocoder string) http.Handler {
+        mux := http.NewServeMux()
+        mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+                isNext := r.URL.Path == "/next"
+
+                var city string
+                var lat, lng float64
+                var hasLatLng bool
+
+                if isNext {
+                        q := r.URL.Query()
+                        city = q.Get("city")
+                        if la, errA := strconv.ParseFloat(q.Get("lat"), 64); errA == nil {
+                                if lo, errB := strconv.ParseFloat(q.Get("lng"), 64); errB == nil {
+                                        lat, lng, hasLatLng = la, lo, true
+                                }
+                        }
+                } else if r.URL.Path != "/" {
+                        city, lat, lng, hasLatLng = parsePathLocation(r.URL.Path)
+                }
+
+                if city == "" && !hasLatLng {
+                        http.Error(w, "usage: /<city>, /<lat>,<lng> or /next?city=<city>", http.StatusBadRequest)
+                        return
+                }
+
+                if city != "" {
+                        resolvedLat, resolvedLng, err := resolveCity(city, geocoder, nil, 0)
+                        if err != nil {
+                                http.Error(w, err.Error(), http.StatusNotFound)
+                                return
+                        }
+                        lat, lng = resolvedLat, resolvedLng
+                }
+
+                pt, err := serveFetch(lat, lng, method, offline)
+                if err != nil {
+                        http.Error(w, err.Error(), http.StatusBadGateway)
+                        return
+                }
+
+                wantsJSON := strings.Contains(r.Header.Get("Accept"), "application/json")
+
+                if isNext {
+                        name, until, err := calculateTimeUntilNextPrayer(pt.Data.Timings)
+                        if err != nil {
+                                http.Error(w, err.Error(), http.StatusInternalServerError)
+                                return
+                        }
+                        if wantsJSON {
+                                writeJSONResponse(w, map[string]any{"next": name, "in_seconds": int(until.Seconds())})
+                                return
+                        }
+                        fmt.Fprintf(w, "%s in %s\n", name, until.Round(time.Second))
+                        return
+                }
+
+                if wantsJSON {
+                        writeJSONResponse(w, pt)
+                        return
+                }
+
+                opts := RenderOptions{ANSI: isConsoleClient(r.UserAgent())}
+                if err := Render(w, pt, opts); err != nil {
+                        http.Error(w, err.Error(), http.StatusInternalServerError)
+                }
+        })
+        return mux
+}
+
+// runServe starts the -serve HTTP mode: a wttr.in-style endpoint that
+// serves console-friendly text to curl/wget/httpie and JSON to everything
+// that asks for it via Accept, warming popular cities in the background.
+func runServe(addr string, method int, offline bool, geocoder string) error {
+        startPrefetchScheduler(method, offline, geocoder)
+        daemonLog.Info("serving", "addr", addr)
+        return http.ListenAndServe(addr, serveMux(method, offline, geocoder))
+}
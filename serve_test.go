@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestIsConsoleClient(t *testing.T) {
+        cases := []struct {
+                ua   string
+                want bool
+        }{
+                {"curl/8.4.0", true},
+                {"Wget/1.21", true},
+                {"HTTPie/3.2", true},
+                {"Mozilla/5.0 (Macintosh)", false},
+                {"", false},
+        }
+        for _, c := range cases {
+                if got := isConsoleClient(c.ua); got != c.want {
+                        t.Errorf("isConsoleClient(%q) = %v, want %v", c.ua, got, c.want)
+                }
+        }
+}
+
+func TestParsePathLocation(t *testing.T) {
+        city, lat, lng, hasLatLng := parsePathLocation("/Cairo")
+        if city != "Cairo" || hasLatLng {
+                t.Errorf("parsePathLocation(/Cairo) = %q, %v, %v, %v", city, lat, lng, hasLatLng)
+        }
+
+        city, lat, lng, hasLatLng = parsePathLocation("/30.0444,31.2357")
+        if !hasLatLng || lat != 30.0444 || lng != 31.2357 || city != "" {
+                t.Errorf("parsePathLocation(/lat,lng) = %q, %v, %v, %v", city, lat, lng, hasLatLng)
+        }
+
+        city, _, _, hasLatLng = parsePathLocation("/not,a,number")
+        if hasLatLng || city != "not,a,number" {
+                t.Errorf("parsePathLocation(/not,a,number) = %q, hasLatLng=%v, want city=%q hasLatLng=false", city, hasLatLng, "not,a,number")
+        }
+}